@@ -8,7 +8,6 @@ package zerozap
 
 import (
 	"encoding/base64"
-	"fmt"
 	"strconv"
 	"time"
 
@@ -34,11 +33,14 @@ func (ap *arrayProxy) MarshalZerologArray(arr *zerolog.Array) {
 }
 
 func (z *zeroArray) AppendArray(marshaler zapcore.ArrayMarshaler) error {
-	// TODO why does zerolog not support nested arrays?
-	//ap := &arrayProxy{arr: marshaler}
-	//z.evt.Array(key, ap)
-	//return ap.err
-	return fmt.Errorf("zerolog doesn't support nested arrays")
+	// zerolog's Array has no way to nest another Array inside it, so the inner array is
+	// buffered into a plain slice by bufferedArray and appended as a reflected value instead.
+	buf := &bufferedArray{}
+	if err := marshaler.MarshalLogArray(buf); err != nil {
+		return err
+	}
+	z.evt.Interface(buf.values)
+	return nil
 }
 
 func (z *zeroArray) AppendObject(marshaler zapcore.ObjectMarshaler) error {
@@ -135,3 +137,120 @@ func (z *zeroArray) AppendReflected(value interface{}) error {
 	z.evt.Interface(value)
 	return nil
 }
+
+// bufferedArray implements [zapcore.ArrayEncoder] by collecting appended values into a plain
+// slice instead of writing to a [zerolog.Array]. It's used by zeroArray.AppendArray to work
+// around zerolog not supporting arrays nested inside arrays: the inner array is buffered here,
+// then replayed into the parent array as a single reflected value.
+type bufferedArray struct {
+	values []any
+}
+
+var _ zapcore.ArrayEncoder = (*bufferedArray)(nil)
+
+func (b *bufferedArray) AppendArray(marshaler zapcore.ArrayMarshaler) error {
+	inner := &bufferedArray{}
+	if err := marshaler.MarshalLogArray(inner); err != nil {
+		return err
+	}
+	b.values = append(b.values, inner.values)
+	return nil
+}
+
+func (b *bufferedArray) AppendObject(marshaler zapcore.ObjectMarshaler) error {
+	inner := &bufferedObject{fields: make(map[string]any)}
+	if err := marshaler.MarshalLogObject(inner); err != nil {
+		return err
+	}
+	b.values = append(b.values, inner.fields)
+	return nil
+}
+
+func (b *bufferedArray) AppendBinary(value []byte) {
+	b.values = append(b.values, base64.StdEncoding.EncodeToString(value))
+}
+
+func (b *bufferedArray) AppendByteString(value []byte) {
+	b.values = append(b.values, string(value))
+}
+
+func (b *bufferedArray) AppendBool(value bool) {
+	b.values = append(b.values, value)
+}
+
+func (b *bufferedArray) AppendComplex128(value complex128) {
+	b.values = append(b.values, strconv.FormatComplex(value, 'f', -1, 128))
+}
+
+func (b *bufferedArray) AppendComplex64(value complex64) {
+	b.values = append(b.values, strconv.FormatComplex(complex128(value), 'f', -1, 64))
+}
+
+func (b *bufferedArray) AppendDuration(value time.Duration) {
+	b.values = append(b.values, value)
+}
+
+func (b *bufferedArray) AppendFloat64(value float64) {
+	b.values = append(b.values, value)
+}
+
+func (b *bufferedArray) AppendFloat32(value float32) {
+	b.values = append(b.values, value)
+}
+
+func (b *bufferedArray) AppendInt(value int) {
+	b.values = append(b.values, value)
+}
+
+func (b *bufferedArray) AppendInt64(value int64) {
+	b.values = append(b.values, value)
+}
+
+func (b *bufferedArray) AppendInt32(value int32) {
+	b.values = append(b.values, value)
+}
+
+func (b *bufferedArray) AppendInt16(value int16) {
+	b.values = append(b.values, value)
+}
+
+func (b *bufferedArray) AppendInt8(value int8) {
+	b.values = append(b.values, value)
+}
+
+func (b *bufferedArray) AppendString(value string) {
+	b.values = append(b.values, value)
+}
+
+func (b *bufferedArray) AppendTime(value time.Time) {
+	b.values = append(b.values, value)
+}
+
+func (b *bufferedArray) AppendUint(value uint) {
+	b.values = append(b.values, value)
+}
+
+func (b *bufferedArray) AppendUint64(value uint64) {
+	b.values = append(b.values, value)
+}
+
+func (b *bufferedArray) AppendUint32(value uint32) {
+	b.values = append(b.values, value)
+}
+
+func (b *bufferedArray) AppendUint16(value uint16) {
+	b.values = append(b.values, value)
+}
+
+func (b *bufferedArray) AppendUint8(value uint8) {
+	b.values = append(b.values, value)
+}
+
+func (b *bufferedArray) AppendUintptr(value uintptr) {
+	b.values = append(b.values, uint64(value))
+}
+
+func (b *bufferedArray) AppendReflected(value interface{}) error {
+	b.values = append(b.values, value)
+	return nil
+}
@@ -0,0 +1,260 @@
+// Copyright (c) 2024 Tulir Asokan
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package zerozap
+
+import (
+	"encoding/base64"
+	"fmt"
+	"math"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog"
+	"go.uber.org/zap/zapcore"
+)
+
+// encoderMu guards fieldEncoders and reflectedEncoders: Register* may be called concurrently with
+// logging (e.g. from a plugin registering its own type while other goroutines already log).
+var encoderMu sync.RWMutex
+
+// FieldEncoder writes a single zapcore.Field of a specific zapcore.FieldType to a
+// [zerolog.Event]. Register one with [RegisterFieldEncoder] to add or override how a field type
+// is encoded.
+//
+// This only covers the types [fieldsToEvent] dispatches through the table below. The types with
+// their own control flow (arrays, objects, embedded objects and namespaces) are handled directly
+// in fieldsToEvent and zeroZap.With and can't be overridden this way.
+//
+// A registered FieldEncoder only takes effect when encoding a freshly written entry
+// (zeroZap.Write). Fields pre-bound with logger.With(...) are dispatched through the separate,
+// non-extensible contextFieldEncoders table instead, since that table runs against a
+// [zerolog.Context] rather than a [zerolog.Event] and a FieldEncoder can't be run against one. See
+// the same caveat on ReflectedEncoder, which has the identical split. The exception is a field
+// pre-bound at or after a zap.Namespace field: With defers it (see zeroZap.pending), and it's
+// encoded with this table at Write time like a freshly written field.
+type FieldEncoder func(f zapcore.Field, evt *zerolog.Event)
+
+// ReflectedEncoder is a fast path for a [zapcore.ReflectType] field (as produced by zap.Any or
+// zap.Reflect) whose value matches Match, registered with [RegisterReflectedEncoder].
+//
+// Without a matching ReflectedEncoder, ReflectType fields fall back to [zerolog.Event.Any], which
+// encodes the value using reflection.
+//
+// A match only takes effect when encoding a freshly written entry (zeroZap.Write). Fields
+// pre-bound with logger.With(...) (other than those deferred after a zap.Namespace field, see
+// FieldEncoder) use the plain reflection-based fallback instead, since
+// Encode writes directly under key, and running it against a throwaway [zerolog.Context] sub-event
+// and then re-attaching that under key would nest the result under key twice.
+type ReflectedEncoder struct {
+	Match  func(value any) bool
+	Encode func(key string, value any, evt *zerolog.Event)
+}
+
+var fieldEncoders = map[zapcore.FieldType]FieldEncoder{
+	zapcore.BinaryType: func(f zapcore.Field, evt *zerolog.Event) {
+		evt.Str(f.Key, base64.StdEncoding.EncodeToString(f.Interface.([]byte)))
+	},
+	zapcore.BoolType: func(f zapcore.Field, evt *zerolog.Event) {
+		evt.Bool(f.Key, f.Integer == 1)
+	},
+	zapcore.ByteStringType: func(f zapcore.Field, evt *zerolog.Event) {
+		evt.Bytes(f.Key, f.Interface.([]byte))
+	},
+	zapcore.Complex128Type: func(f zapcore.Field, evt *zerolog.Event) {
+		evt.Str(f.Key, strconv.FormatComplex(f.Interface.(complex128), 'f', -1, 128))
+	},
+	zapcore.Complex64Type: func(f zapcore.Field, evt *zerolog.Event) {
+		evt.Str(f.Key, strconv.FormatComplex(complex128(f.Interface.(complex64)), 'f', -1, 64))
+	},
+	zapcore.DurationType: func(f zapcore.Field, evt *zerolog.Event) {
+		evt.Dur(f.Key, time.Duration(f.Integer))
+	},
+	zapcore.Float64Type: func(f zapcore.Field, evt *zerolog.Event) {
+		evt.Float64(f.Key, math.Float64frombits(uint64(f.Integer)))
+	},
+	zapcore.Float32Type: func(f zapcore.Field, evt *zerolog.Event) {
+		evt.Float32(f.Key, math.Float32frombits(uint32(f.Integer)))
+	},
+	zapcore.Int64Type:   intFieldEncoder,
+	zapcore.Int32Type:   intFieldEncoder,
+	zapcore.Int16Type:   intFieldEncoder,
+	zapcore.Int8Type:    intFieldEncoder,
+	zapcore.Uint64Type:  uintFieldEncoder,
+	zapcore.Uint32Type:  uintFieldEncoder,
+	zapcore.Uint16Type:  uintFieldEncoder,
+	zapcore.Uint8Type:   uintFieldEncoder,
+	zapcore.UintptrType: uintFieldEncoder,
+	zapcore.StringType: func(f zapcore.Field, evt *zerolog.Event) {
+		evt.Str(f.Key, f.String)
+	},
+	zapcore.TimeType: func(f zapcore.Field, evt *zerolog.Event) {
+		if f.Interface != nil {
+			evt.Time(f.Key, time.Unix(0, f.Integer).In(f.Interface.(*time.Location)))
+		} else {
+			// Without a location, time.Unix gives the local time zone.
+			evt.Time(f.Key, time.Unix(0, f.Integer))
+		}
+	},
+	zapcore.TimeFullType: func(f zapcore.Field, evt *zerolog.Event) {
+		evt.Time(f.Key, f.Interface.(time.Time))
+	},
+	zapcore.ReflectType: func(f zapcore.Field, evt *zerolog.Event) {
+		encoderMu.RLock()
+		encoders := reflectedEncoders
+		encoderMu.RUnlock()
+		for _, renc := range encoders {
+			if renc.Match(f.Interface) {
+				renc.Encode(f.Key, f.Interface, evt)
+				return
+			}
+		}
+		evt.Any(f.Key, f.Interface)
+	},
+	zapcore.StringerType: func(f zapcore.Field, evt *zerolog.Event) {
+		// A panicking String method is recovered by the caller, safeEncode.
+		evt.Stringer(f.Key, f.Interface.(fmt.Stringer))
+	},
+	zapcore.ErrorType: func(f zapcore.Field, evt *zerolog.Event) {
+		evt.AnErr(f.Key, f.Interface.(error))
+	},
+	zapcore.SkipType: func(f zapcore.Field, evt *zerolog.Event) {},
+}
+
+func intFieldEncoder(f zapcore.Field, evt *zerolog.Event) {
+	evt.Int64(f.Key, f.Integer)
+}
+
+func uintFieldEncoder(f zapcore.Field, evt *zerolog.Event) {
+	evt.Uint64(f.Key, uint64(f.Integer))
+}
+
+var reflectedEncoders []ReflectedEncoder
+
+// RegisterFieldEncoder overrides (or, for an unrecognized [zapcore.FieldType], adds) how fields
+// of the given type are written to the zerolog event. This lets downstream users add fast paths
+// for their own field types without patching this module, and, for any type this package doesn't
+// already know about, avoids falling back to the slower [zerolog.Event.Any] reflection path.
+//
+// This only changes encoding for freshly written entries. A field of type t that's pre-bound with
+// logger.With(...), and not after a zap.Namespace field, still uses the fixed encoding in contextFieldEncoders, unaffected by fn; see the
+// caveat on FieldEncoder.
+func RegisterFieldEncoder(t zapcore.FieldType, fn FieldEncoder) {
+	encoderMu.Lock()
+	defer encoderMu.Unlock()
+	fieldEncoders[t] = fn
+}
+
+// RegisterReflectedEncoder adds a fast path for [zapcore.ReflectType] fields (from zap.Any or
+// zap.Reflect) whose value matches match, e.g. for common domain types like uuid.UUID or
+// protobuf messages. Encoders are tried in the order they were registered; the first match wins.
+func RegisterReflectedEncoder(match func(value any) bool, fn func(key string, value any, evt *zerolog.Event)) {
+	encoderMu.Lock()
+	defer encoderMu.Unlock()
+	reflectedEncoders = append(reflectedEncoders, ReflectedEncoder{Match: match, Encode: fn})
+}
+
+// encodeField writes a single field using the registered table, falling back to
+// [zerolog.Event.Any] for any zapcore.FieldType this package and its callers don't know about.
+func encodeField(f zapcore.Field, evt *zerolog.Event) {
+	encoderMu.RLock()
+	enc, ok := fieldEncoders[f.Type]
+	encoderMu.RUnlock()
+	if ok {
+		enc(f, evt)
+		return
+	}
+	evt.Any(f.Key, f.Interface)
+}
+
+// contextFieldEncoder is the zerolog.Context counterpart of FieldEncoder, used by zeroZap.With
+// to build a pre-bound logger. It isn't user-extensible like the Event-based table above (there is
+// no [RegisterContextFieldEncoder]): a FieldEncoder is written against a [zerolog.Event], and
+// [zerolog.Context] exposes no way to run one against it, so RegisterFieldEncoder has no effect
+// here. This is the same split documented on FieldEncoder and ReflectedEncoder.
+type contextFieldEncoder func(f zapcore.Field, logWith zerolog.Context) zerolog.Context
+
+var contextFieldEncoders = map[zapcore.FieldType]contextFieldEncoder{
+	zapcore.BinaryType: func(f zapcore.Field, logWith zerolog.Context) zerolog.Context {
+		return logWith.Str(f.Key, base64.StdEncoding.EncodeToString(f.Interface.([]byte)))
+	},
+	zapcore.BoolType: func(f zapcore.Field, logWith zerolog.Context) zerolog.Context {
+		return logWith.Bool(f.Key, f.Integer == 1)
+	},
+	zapcore.ByteStringType: func(f zapcore.Field, logWith zerolog.Context) zerolog.Context {
+		return logWith.Bytes(f.Key, f.Interface.([]byte))
+	},
+	zapcore.Complex128Type: func(f zapcore.Field, logWith zerolog.Context) zerolog.Context {
+		return logWith.Str(f.Key, strconv.FormatComplex(f.Interface.(complex128), 'f', -1, 128))
+	},
+	zapcore.Complex64Type: func(f zapcore.Field, logWith zerolog.Context) zerolog.Context {
+		return logWith.Str(f.Key, strconv.FormatComplex(complex128(f.Interface.(complex64)), 'f', -1, 64))
+	},
+	zapcore.DurationType: func(f zapcore.Field, logWith zerolog.Context) zerolog.Context {
+		return logWith.Dur(f.Key, time.Duration(f.Integer))
+	},
+	zapcore.Float64Type: func(f zapcore.Field, logWith zerolog.Context) zerolog.Context {
+		return logWith.Float64(f.Key, math.Float64frombits(uint64(f.Integer)))
+	},
+	zapcore.Float32Type: func(f zapcore.Field, logWith zerolog.Context) zerolog.Context {
+		return logWith.Float32(f.Key, math.Float32frombits(uint32(f.Integer)))
+	},
+	zapcore.Int64Type:   contextIntFieldEncoder,
+	zapcore.Int32Type:   contextIntFieldEncoder,
+	zapcore.Int16Type:   contextIntFieldEncoder,
+	zapcore.Int8Type:    contextIntFieldEncoder,
+	zapcore.Uint64Type:  contextUintFieldEncoder,
+	zapcore.Uint32Type:  contextUintFieldEncoder,
+	zapcore.Uint16Type:  contextUintFieldEncoder,
+	zapcore.Uint8Type:   contextUintFieldEncoder,
+	zapcore.UintptrType: contextUintFieldEncoder,
+	zapcore.StringType: func(f zapcore.Field, logWith zerolog.Context) zerolog.Context {
+		return logWith.Str(f.Key, f.String)
+	},
+	zapcore.TimeType: func(f zapcore.Field, logWith zerolog.Context) zerolog.Context {
+		if f.Interface != nil {
+			return logWith.Time(f.Key, time.Unix(0, f.Integer).In(f.Interface.(*time.Location)))
+		}
+		// Without a location, time.Unix gives the local time zone.
+		return logWith.Time(f.Key, time.Unix(0, f.Integer))
+	},
+	zapcore.TimeFullType: func(f zapcore.Field, logWith zerolog.Context) zerolog.Context {
+		return logWith.Time(f.Key, f.Interface.(time.Time))
+	},
+	zapcore.ReflectType: func(f zapcore.Field, logWith zerolog.Context) zerolog.Context {
+		// Unlike the Event-based table above, registered ReflectedEncoders don't apply here; see
+		// the caveat on ReflectedEncoder.
+		return logWith.Any(f.Key, f.Interface)
+	},
+	zapcore.StringerType: func(f zapcore.Field, logWith zerolog.Context) zerolog.Context {
+		// A panicking String method is recovered by the caller, safeEncodeWith.
+		return logWith.Stringer(f.Key, f.Interface.(fmt.Stringer))
+	},
+	zapcore.ErrorType: func(f zapcore.Field, logWith zerolog.Context) zerolog.Context {
+		return logWith.AnErr(f.Key, f.Interface.(error))
+	},
+	zapcore.SkipType: func(f zapcore.Field, logWith zerolog.Context) zerolog.Context {
+		return logWith
+	},
+}
+
+func contextIntFieldEncoder(f zapcore.Field, logWith zerolog.Context) zerolog.Context {
+	return logWith.Int64(f.Key, f.Integer)
+}
+
+func contextUintFieldEncoder(f zapcore.Field, logWith zerolog.Context) zerolog.Context {
+	return logWith.Uint64(f.Key, uint64(f.Integer))
+}
+
+// encodeContextField is the zerolog.Context counterpart of encodeField. Unlike encodeField, it
+// doesn't consult the RegisterFieldEncoder registry; see the caveat on contextFieldEncoder.
+func encodeContextField(f zapcore.Field, logWith zerolog.Context) zerolog.Context {
+	if enc, ok := contextFieldEncoders[f.Type]; ok {
+		return enc(f, logWith)
+	}
+	return logWith.Any(f.Key, f.Interface)
+}
@@ -0,0 +1,90 @@
+// Copyright (c) 2024 Tulir Asokan
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package zerozap
+
+import (
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// TestRegisterFieldEncoder_NotConsultedByWith pins the documented caveat on FieldEncoder: a
+// registered encoder only applies to freshly written fields, not ones pre-bound via With.
+func TestRegisterFieldEncoder_NotConsultedByWith(t *testing.T) {
+	original := fieldEncoders[zapcore.StringType]
+	t.Cleanup(func() { RegisterFieldEncoder(zapcore.StringType, original) })
+	RegisterFieldEncoder(zapcore.StringType, func(f zapcore.Field, evt *zerolog.Event) {
+		evt.Str(f.Key, strings.ToUpper(f.String))
+	})
+
+	var direct, withBound strings.Builder
+	zap.New(New(zerolog.New(&direct))).Info("msg", zap.String("s", "meow"))
+	zap.New(New(zerolog.New(&withBound))).With(zap.String("s", "meow")).Info("msg")
+
+	if !strings.Contains(direct.String(), `"s":"MEOW"`) {
+		t.Errorf("expected the registered encoder to apply to a freshly written field, got %s", direct.String())
+	}
+	if !strings.Contains(withBound.String(), `"s":"meow"`) {
+		t.Errorf("expected a With-bound field to still use the default encoder, got %s", withBound.String())
+	}
+}
+
+// switchEncodeField is the pre-dispatch-table implementation of encodeField, kept here only to
+// benchmark the table lookup against the switch it replaced.
+func switchEncodeField(f zapcore.Field, evt *zerolog.Event) {
+	switch f.Type {
+	case zapcore.Int64Type, zapcore.Int32Type, zapcore.Int16Type, zapcore.Int8Type:
+		evt.Int64(f.Key, f.Integer)
+	case zapcore.StringType:
+		evt.Str(f.Key, f.String)
+	case zapcore.BoolType:
+		evt.Bool(f.Key, f.Integer == 1)
+	case zapcore.ErrorType:
+		evt.AnErr(f.Key, f.Interface.(error))
+	default:
+		evt.Any(f.Key, f.Interface)
+	}
+}
+
+func benchmarkFields() []zapcore.Field {
+	return []zapcore.Field{
+		zap.Int("int", 42),
+		zap.String("str", "meow"),
+		zap.Bool("bool", true),
+		zap.Error(io.EOF),
+	}
+}
+
+func BenchmarkEncodeField_Table(b *testing.B) {
+	log := zerolog.New(io.Discard)
+	fields := benchmarkFields()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		evt := log.Info()
+		for _, f := range fields {
+			encodeField(f, evt)
+		}
+		evt.Discard().Send()
+	}
+}
+
+func BenchmarkEncodeField_Switch(b *testing.B) {
+	log := zerolog.New(io.Discard)
+	fields := benchmarkFields()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		evt := log.Info()
+		for _, f := range fields {
+			switchEncodeField(f, evt)
+		}
+		evt.Discard().Send()
+	}
+}
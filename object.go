@@ -151,3 +151,126 @@ func (z *zeroObject) OpenNamespace(key string) {
 		}
 	}
 }
+
+// bufferedObject implements [zapcore.ObjectEncoder] by collecting fields into a plain map
+// instead of writing to a [zerolog.Event]. It's used by bufferedArray.AppendObject so that an
+// object nested inside an array-inside-an-array ends up as ordinary data that can be replayed
+// via Event.Interface alongside the rest of the buffered array.
+type bufferedObject struct {
+	fields map[string]any
+}
+
+var _ zapcore.ObjectEncoder = (*bufferedObject)(nil)
+
+func (b *bufferedObject) AddArray(key string, marshaler zapcore.ArrayMarshaler) error {
+	inner := &bufferedArray{}
+	if err := marshaler.MarshalLogArray(inner); err != nil {
+		return err
+	}
+	b.fields[key] = inner.values
+	return nil
+}
+
+func (b *bufferedObject) AddObject(key string, marshaler zapcore.ObjectMarshaler) error {
+	inner := &bufferedObject{fields: make(map[string]any)}
+	if err := marshaler.MarshalLogObject(inner); err != nil {
+		return err
+	}
+	b.fields[key] = inner.fields
+	return nil
+}
+
+func (b *bufferedObject) AddBinary(key string, value []byte) {
+	b.fields[key] = base64.StdEncoding.EncodeToString(value)
+}
+
+func (b *bufferedObject) AddByteString(key string, value []byte) {
+	b.fields[key] = string(value)
+}
+
+func (b *bufferedObject) AddBool(key string, value bool) {
+	b.fields[key] = value
+}
+
+func (b *bufferedObject) AddComplex128(key string, value complex128) {
+	b.fields[key] = strconv.FormatComplex(value, 'f', -1, 128)
+}
+
+func (b *bufferedObject) AddComplex64(key string, value complex64) {
+	b.fields[key] = strconv.FormatComplex(complex128(value), 'f', -1, 64)
+}
+
+func (b *bufferedObject) AddDuration(key string, value time.Duration) {
+	b.fields[key] = value
+}
+
+func (b *bufferedObject) AddFloat64(key string, value float64) {
+	b.fields[key] = value
+}
+
+func (b *bufferedObject) AddFloat32(key string, value float32) {
+	b.fields[key] = value
+}
+
+func (b *bufferedObject) AddInt(key string, value int) {
+	b.fields[key] = value
+}
+
+func (b *bufferedObject) AddInt64(key string, value int64) {
+	b.fields[key] = value
+}
+
+func (b *bufferedObject) AddInt32(key string, value int32) {
+	b.fields[key] = value
+}
+
+func (b *bufferedObject) AddInt16(key string, value int16) {
+	b.fields[key] = value
+}
+
+func (b *bufferedObject) AddInt8(key string, value int8) {
+	b.fields[key] = value
+}
+
+func (b *bufferedObject) AddString(key, value string) {
+	b.fields[key] = value
+}
+
+func (b *bufferedObject) AddTime(key string, value time.Time) {
+	b.fields[key] = value
+}
+
+func (b *bufferedObject) AddUint(key string, value uint) {
+	b.fields[key] = value
+}
+
+func (b *bufferedObject) AddUint64(key string, value uint64) {
+	b.fields[key] = value
+}
+
+func (b *bufferedObject) AddUint32(key string, value uint32) {
+	b.fields[key] = value
+}
+
+func (b *bufferedObject) AddUint16(key string, value uint16) {
+	b.fields[key] = value
+}
+
+func (b *bufferedObject) AddUint8(key string, value uint8) {
+	b.fields[key] = value
+}
+
+func (b *bufferedObject) AddUintptr(key string, value uintptr) {
+	b.fields[key] = uint64(value)
+}
+
+func (b *bufferedObject) AddReflected(key string, value interface{}) error {
+	b.fields[key] = value
+	return nil
+}
+
+func (b *bufferedObject) OpenNamespace(key string) {
+	sub := make(map[string]any)
+	b.fields[key] = sub
+	b.fields = sub
+}
@@ -0,0 +1,113 @@
+// Copyright (c) 2024 Tulir Asokan
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package zerozap
+
+import (
+	"github.com/rs/zerolog"
+	"go.uber.org/zap/zapcore"
+)
+
+// CallerMarshalFunc formats a caller's program counter and source location into the string
+// copied into [zerolog.CallerFieldName], matching the signature of zerolog's own package-level
+// CallerMarshalFunc variable.
+type CallerMarshalFunc func(pc uintptr, file string, line int) string
+
+type options struct {
+	copyTime   bool
+	copyStack  bool
+	copyCaller bool
+
+	levelMap        map[zapcore.Level]zerolog.Level
+	callerMarshaler CallerMarshalFunc
+	stackFieldName  string
+	errorHandler    func(error)
+}
+
+func defaultOptions() options {
+	return options{
+		copyTime:   CopyTime,
+		copyStack:  CopyStack,
+		copyCaller: CopyCaller,
+
+		levelMap:        levelMap,
+		callerMarshaler: zerolog.CallerMarshalFunc,
+		stackFieldName:  zerolog.ErrorStackFieldName,
+	}
+}
+
+// CoreOption configures optional behavior of a core created with [New].
+type CoreOption func(*options)
+
+// WithCopyTime returns a [CoreOption] that sets whether the time field from zap is copied to
+// zerolog. The time will be placed in [zerolog.TimestampFieldName].
+func WithCopyTime(copy bool) CoreOption {
+	return func(o *options) {
+		o.copyTime = copy
+	}
+}
+
+// WithCopyStack returns a [CoreOption] that sets whether the stack field from zap (if present)
+// is copied to zerolog. The stack string will be placed in the field named by
+// [WithStackFieldName] (or [zerolog.ErrorStackFieldName] by default).
+func WithCopyStack(copy bool) CoreOption {
+	return func(o *options) {
+		o.copyStack = copy
+	}
+}
+
+// WithCopyCaller returns a [CoreOption] that sets whether the caller field from zap (if present)
+// is copied to zerolog. The caller info will be placed in [zerolog.CallerFieldName] after being
+// marshaled using the marshaler set by [WithCallerMarshaler].
+func WithCopyCaller(copy bool) CoreOption {
+	return func(o *options) {
+		o.copyCaller = copy
+	}
+}
+
+// WithCallerMarshaler returns a [CoreOption] that overrides the function used to format the
+// caller field copied by [WithCopyCaller]. It defaults to [zerolog.CallerMarshalFunc].
+func WithCallerMarshaler(fn CallerMarshalFunc) CoreOption {
+	return func(o *options) {
+		o.callerMarshaler = fn
+	}
+}
+
+// WithLevelMap returns a [CoreOption] that overrides the mapping from zap levels to zerolog
+// levels used by both [zapcore.Core.Enabled] and [zapcore.Core.Write]. m is merged over the
+// default map (which covers every [zapcore.Level]), so remapping a single level doesn't leave the
+// others unmapped.
+func WithLevelMap(m map[zapcore.Level]zerolog.Level) CoreOption {
+	return func(o *options) {
+		merged := make(map[zapcore.Level]zerolog.Level, len(levelMap))
+		for level, zlevel := range levelMap {
+			merged[level] = zlevel
+		}
+		for level, zlevel := range m {
+			merged[level] = zlevel
+		}
+		o.levelMap = merged
+	}
+}
+
+// WithStackFieldName returns a [CoreOption] that overrides the field name the stack copied by
+// [WithCopyStack] is placed under. It defaults to [zerolog.ErrorStackFieldName].
+func WithStackFieldName(name string) CoreOption {
+	return func(o *options) {
+		o.stackFieldName = name
+	}
+}
+
+// WithErrorHandler returns a [CoreOption] that routes a panic recovered while encoding a single
+// field (e.g. from a failing [zapcore.ArrayMarshaler]/[zapcore.ObjectMarshaler] or a bad type
+// assertion on a corrupt [zapcore.Field]) to fn, wrapped as an error, instead
+// of the default behavior of writing "<key>Error": "<panic message>" into the log entry. Either
+// way, one malformed field can no longer crash the process or abort the rest of the log call.
+func WithErrorHandler(fn func(error)) CoreOption {
+	return func(o *options) {
+		o.errorHandler = fn
+	}
+}
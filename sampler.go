@@ -0,0 +1,213 @@
+// Copyright (c) 2024 Tulir Asokan
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package zerozap
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/rs/zerolog"
+	"go.uber.org/zap/zapcore"
+)
+
+// samplerShardCount is the number of shards the sampling counters are split across.
+// Sharding keeps lock contention low when many goroutines log concurrently.
+const samplerShardCount = 32
+
+type samplerKey struct {
+	level   zapcore.Level
+	message string
+}
+
+type samplerCounter struct {
+	resetAt atomic.Int64
+	count   atomic.Uint64
+}
+
+// incCheckReset bumps the counter for the tick window containing now, starting a fresh window
+// (and counting this call as its first) if the previous one has elapsed.
+//
+// The count is reset to 1 *before* the resetAt CAS, mirroring zap's zapcore.counter.IncCheckReset:
+// that way, a goroutine that loses the CAS race (because another one already reset the window)
+// re-increments from the new baseline instead of bumping a count that's about to be zeroed out
+// from under it, which would otherwise let a call be wrongly dropped right at a tick boundary.
+func (c *samplerCounter) incCheckReset(now, tick int64) uint64 {
+	resetAt := c.resetAt.Load()
+	if resetAt > now {
+		return c.count.Add(1)
+	}
+	c.count.Store(1)
+	if !c.resetAt.CompareAndSwap(resetAt, now+tick) {
+		// Another goroutine raced us and already reset the window.
+		return c.count.Add(1)
+	}
+	return 1
+}
+
+// samplerShardEvictThreshold caps how large a shard's counter map is allowed to grow before a
+// sweep for expired entries runs. Without this, a shard only ever grows: the per-key counter is
+// reset on each elapsed tick window, but the key itself is never forgotten, which leaks memory for
+// workloads whose message isn't a small fixed set of string literals (e.g. one built with
+// fmt.Sprintf). See NewSampled.
+const samplerShardEvictThreshold = 4096
+
+type samplerShard struct {
+	mu       sync.Mutex
+	counters map[samplerKey]*samplerCounter
+}
+
+func (s *samplerShard) get(key samplerKey, now int64) *samplerCounter {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	counter, ok := s.counters[key]
+	if ok {
+		return counter
+	}
+	if len(s.counters) >= samplerShardEvictThreshold {
+		s.evictExpiredLocked(now)
+	}
+	counter = &samplerCounter{}
+	s.counters[key] = counter
+	return counter
+}
+
+// evictExpiredLocked removes every counter whose tick window has already elapsed (i.e. hasn't been
+// incremented since), bounding the shard's memory use. Evicting one is equivalent to the reset
+// incCheckReset would have given it on its next increment, so this doesn't change sampling
+// behavior, only how long a stale key's counter is kept around. s.mu must be held by the caller.
+func (s *samplerShard) evictExpiredLocked(now int64) {
+	for key, counter := range s.counters {
+		if counter.resetAt.Load() <= now {
+			delete(s.counters, key)
+		}
+	}
+}
+
+type samplerOptions struct {
+	onDropped func(ent zapcore.Entry)
+}
+
+// SamplerOption configures optional behavior of [NewSampled].
+type SamplerOption func(*samplerOptions)
+
+// WithSamplerDropHook returns a [SamplerOption] that calls fn for every entry the sampler drops.
+// This is useful for keeping metrics of how much log volume is being discarded.
+func WithSamplerDropHook(fn func(ent zapcore.Entry)) SamplerOption {
+	return func(o *samplerOptions) {
+		o.onDropped = fn
+	}
+}
+
+type sampler struct {
+	core zapcore.Core
+
+	tick       time.Duration
+	first      uint64
+	thereafter uint64
+	opts       samplerOptions
+
+	shards *[samplerShardCount]samplerShard
+}
+
+var _ zapcore.Core = (*sampler)(nil)
+
+// NewSampled wraps a [zerozap] core (as created by [New]) with rate limiting analogous to
+// [zapcore.NewSamplerWithOptions]: within each tick window, the first entries with a given
+// level and message are admitted, and after that only every thereafter-th entry with the same
+// level and message is admitted, with the rest dropped before they ever reach zerolog.
+//
+// This keeps log volume bounded for code paths that may log the same message at a very high
+// rate (e.g. a retry loop), the same way production zap deployments routinely do.
+//
+// Each distinct level+message pair gets its own counter, kept until its tick window goes stale
+// (see samplerShardEvictThreshold), so memory use scales with the number of distinct messages
+// actually in flight within a tick rather than growing forever; it's still best to keep message
+// cardinality bounded (e.g. avoid building the message with fmt.Sprintf) rather than relying on
+// eviction alone.
+func NewSampled(log zerolog.Logger, tick time.Duration, first, thereafter int, opts ...SamplerOption) zapcore.Core {
+	s := &sampler{
+		core:       New(log),
+		tick:       tick,
+		first:      uint64(first),
+		thereafter: uint64(thereafter),
+		shards:     &[samplerShardCount]samplerShard{},
+	}
+	for i := range s.shards {
+		s.shards[i].counters = make(map[samplerKey]*samplerCounter)
+	}
+	for _, opt := range opts {
+		opt(&s.opts)
+	}
+	return s
+}
+
+func (s *sampler) shardFor(key samplerKey) *samplerShard {
+	h := fnv32a(key.message) ^ uint32(key.level)
+	return &s.shards[h%samplerShardCount]
+}
+
+func fnv32a(s string) uint32 {
+	const (
+		offset32 = 2166136261
+		prime32  = 16777619
+	)
+	hash := uint32(offset32)
+	for i := 0; i < len(s); i++ {
+		hash ^= uint32(s[i])
+		hash *= prime32
+	}
+	return hash
+}
+
+// admit reports whether the entry should be passed through, bumping the counter for its
+// level+message key and resetting it if the current tick window has elapsed.
+func (s *sampler) admit(ent zapcore.Entry) bool {
+	key := samplerKey{level: ent.Level, message: ent.Message}
+	now := ent.Time.UnixNano()
+	counter := s.shardFor(key).get(key, now)
+
+	n := counter.incCheckReset(now, int64(s.tick))
+	if n <= s.first {
+		return true
+	}
+	if s.thereafter == 0 {
+		return false
+	}
+	return (n-s.first)%s.thereafter == 0
+}
+
+func (s *sampler) Enabled(level zapcore.Level) bool {
+	return s.core.Enabled(level)
+}
+
+func (s *sampler) With(fields []zapcore.Field) zapcore.Core {
+	clone := *s
+	clone.core = s.core.With(fields)
+	return &clone
+}
+
+func (s *sampler) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if !s.Enabled(ent.Level) {
+		return ce
+	}
+	return ce.AddCore(ent, s)
+}
+
+func (s *sampler) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	if !s.admit(ent) {
+		if s.opts.onDropped != nil {
+			s.opts.onDropped(ent)
+		}
+		return nil
+	}
+	return s.core.Write(ent, fields)
+}
+
+func (s *sampler) Sync() error {
+	return s.core.Sync()
+}
@@ -0,0 +1,39 @@
+// Copyright (c) 2024 Tulir Asokan
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package zerozap
+
+import (
+	"strconv"
+	"testing"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// TestSamplerShard_EvictsExpired confirms a shard sweeps out counters whose tick window has
+// already elapsed once it grows past samplerShardEvictThreshold, instead of keeping every
+// level+message key it has ever seen forever.
+func TestSamplerShard_EvictsExpired(t *testing.T) {
+	shard := &samplerShard{counters: make(map[samplerKey]*samplerCounter)}
+
+	for i := 0; i < samplerShardEvictThreshold; i++ {
+		key := samplerKey{level: zapcore.InfoLevel, message: strconv.Itoa(i)}
+		counter := shard.get(key, 0)
+		// Expire this key's window immediately so it's eligible for eviction.
+		counter.resetAt.Store(0)
+	}
+	if got := len(shard.counters); got != samplerShardEvictThreshold {
+		t.Fatalf("expected %d counters before eviction, got %d", samplerShardEvictThreshold, got)
+	}
+
+	// Crossing the threshold again triggers a sweep; every existing counter's window has already
+	// elapsed (resetAt == 0 <= now), so they should all be gone, leaving only the new key.
+	shard.get(samplerKey{level: zapcore.InfoLevel, message: "new"}, 1)
+
+	if got := len(shard.counters); got != 1 {
+		t.Errorf("expected stale counters to be evicted, got %d left", got)
+	}
+}
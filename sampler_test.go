@@ -0,0 +1,51 @@
+// Copyright (c) 2024 Tulir Asokan
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package zerozap_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+
+	"go.mau.fi/zerozap"
+)
+
+func TestNewSampled(t *testing.T) {
+	var buf strings.Builder
+	var dropped int
+
+	core := zerozap.NewSampled(
+		zerolog.New(&buf),
+		time.Minute,
+		2,
+		3,
+		zerozap.WithSamplerDropHook(func(zapcore.Entry) {
+			dropped++
+		}),
+	)
+	logger := zap.New(core)
+
+	for i := 0; i < 10; i++ {
+		logger.Info("Spam")
+	}
+
+	const expected = `{"level":"info","message":"Spam"}
+{"level":"info","message":"Spam"}
+{"level":"info","message":"Spam"}
+{"level":"info","message":"Spam"}
+`
+	if out := buf.String(); out != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, out)
+	}
+	if dropped != 6 {
+		t.Errorf("expected 6 dropped entries, got %d", dropped)
+	}
+}
@@ -0,0 +1,268 @@
+// Copyright (c) 2024 Tulir Asokan
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+// Package zeroslog implements a [log/slog.Handler] on top of a [zerolog.Logger],
+// the same way the top-level zerozap package implements a [zapcore.Core].
+package zeroslog
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"runtime"
+
+	"github.com/rs/zerolog"
+)
+
+// DefaultDetailsFieldSuffix is the default suffix appended to the key of the companion field
+// emitted for a structured error, i.e. an error that also implements [slog.LogValuer].
+const DefaultDetailsFieldSuffix = "Details"
+
+func zerologLevel(level slog.Level) zerolog.Level {
+	switch {
+	case level >= slog.LevelError:
+		return zerolog.ErrorLevel
+	case level >= slog.LevelWarn:
+		return zerolog.WarnLevel
+	case level >= slog.LevelInfo:
+		return zerolog.InfoLevel
+	default:
+		return zerolog.DebugLevel
+	}
+}
+
+// Handler is a [slog.Handler] that writes through to a [zerolog.Logger].
+type Handler struct {
+	log  zerolog.Logger
+	opts slog.HandlerOptions
+	goa  []groupOrAttrs
+
+	detailsFieldSuffix string
+}
+
+// groupOrAttrs is one entry in the chain of groups opened (WithGroup) and attrs bound (WithAttrs)
+// on a Handler, in the order they were applied. Either group is set (the entry opened a group) or
+// attrs is (the entry bound attrs at the nesting depth current at the time).
+//
+// Handle renders the whole chain fresh for every record instead of baking attrs into a sealed
+// zerolog.Context up front: a zerolog.Context.Dict is sealed the moment it's attached, so eagerly
+// nesting WithAttrs' attrs under the open groups could never be reopened by a later WithAttrs/Handle call
+// for the same group, only re-wrapped into a sibling Dict under a duplicate key. Buffering instead
+// and rendering once per record mirrors how [slog.JSONHandler] itself defers its groupOrAttrs chain.
+type groupOrAttrs struct {
+	group string
+	attrs []slog.Attr
+}
+
+var _ slog.Handler = (*Handler)(nil)
+
+// New creates a new [Handler] that writes to the given zerolog instance.
+//
+// opts may be nil, in which case the defaults (info level, no source) are used.
+func New(log zerolog.Logger, opts *slog.HandlerOptions) *Handler {
+	h := &Handler{
+		log:                log,
+		detailsFieldSuffix: DefaultDetailsFieldSuffix,
+	}
+	if opts != nil {
+		h.opts = *opts
+	}
+	return h
+}
+
+// WithDetailsFieldSuffix returns a shallow copy of h that appends suffix (instead of
+// [DefaultDetailsFieldSuffix]) to the companion field emitted for a structured error.
+func (h *Handler) WithDetailsFieldSuffix(suffix string) *Handler {
+	clone := *h
+	clone.detailsFieldSuffix = suffix
+	return &clone
+}
+
+func (h *Handler) Enabled(_ context.Context, level slog.Level) bool {
+	minLevel := slog.LevelInfo
+	if h.opts.Level != nil {
+		minLevel = h.opts.Level.Level()
+	}
+	return level >= minLevel
+}
+
+func (h *Handler) Handle(_ context.Context, record slog.Record) error {
+	evt := h.log.WithLevel(zerologLevel(record.Level))
+	if !record.Time.IsZero() {
+		evt.Time(zerolog.TimestampFieldName, record.Time)
+	}
+	if h.opts.AddSource && record.PC != 0 {
+		frames := runtime.CallersFrames([]uintptr{record.PC})
+		frame, _ := frames.Next()
+		if frame.PC != 0 {
+			evt.Str(zerolog.CallerFieldName, zerolog.CallerMarshalFunc(frame.PC, frame.File, frame.Line))
+		}
+	}
+	attrs := make([]slog.Attr, 0, record.NumAttrs())
+	record.Attrs(func(attr slog.Attr) bool {
+		attrs = append(attrs, attr)
+		return true
+	})
+	h.render(evt, h.goa, attrs)
+	evt.Msg(record.Message)
+	return nil
+}
+
+func (h *Handler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	if len(attrs) == 0 {
+		return h
+	}
+	clone := *h
+	clone.goa = append(append([]groupOrAttrs{}, h.goa...), groupOrAttrs{attrs: attrs})
+	return &clone
+}
+
+func (h *Handler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	clone := *h
+	clone.goa = append(append([]groupOrAttrs{}, h.goa...), groupOrAttrs{group: name})
+	return &clone
+}
+
+// render replays goa (the chain of groups opened and attrs bound by WithGroup/WithAttrs, oldest
+// first), then attrs (the record's own, nested under whatever groups are still open at the end of
+// the chain), writing a Dict for every open group along the way. A group that ends up with nothing
+// in it (e.g. WithGroup never followed by any attrs) is omitted entirely rather than written out as
+// an empty object, matching the "only output non-empty groups" convention slog.JSONHandler follows.
+//
+// The returned bool reports whether render wrote anything to evt, so a parent group can apply the
+// same omit-if-empty rule to itself.
+func (h *Handler) render(evt *zerolog.Event, goa []groupOrAttrs, attrs []slog.Attr) bool {
+	if len(goa) == 0 {
+		wrote := false
+		for _, attr := range attrs {
+			if attr.Equal(slog.Attr{}) {
+				continue
+			}
+			h.addAttrToEvent(evt, attr)
+			wrote = true
+		}
+		return wrote
+	}
+	if goa[0].group == "" {
+		wrote := false
+		for _, attr := range goa[0].attrs {
+			if attr.Equal(slog.Attr{}) {
+				continue
+			}
+			h.addAttrToEvent(evt, attr)
+			wrote = true
+		}
+		if h.render(evt, goa[1:], attrs) {
+			wrote = true
+		}
+		return wrote
+	}
+	dict := zerolog.Dict()
+	if !h.render(dict, goa[1:], attrs) {
+		return false
+	}
+	evt.Dict(goa[0].group, dict)
+	return true
+}
+
+// addAttrToEvent translates a single slog.Attr to the corresponding zerolog.Event method,
+// mirroring how zerozap's fieldsToEvent dispatches on zapcore.FieldType.
+func (h *Handler) addAttrToEvent(evt *zerolog.Event, attr slog.Attr) {
+	if attr.Equal(slog.Attr{}) {
+		return
+	}
+	if attr.Value.Kind() == slog.KindLogValuer {
+		if err, ok := attr.Value.LogValuer().(error); ok {
+			h.addStructuredErrorToEvent(evt, attr.Key, err)
+			return
+		}
+	}
+	h.addValueToEvent(evt, attr.Key, attr.Value.Resolve())
+}
+
+// addValueToEvent translates a single (already-resolved) slog.Value to the corresponding
+// zerolog.Event method, mirroring how zerozap's fieldsToEvent dispatches on zapcore.FieldType.
+func (h *Handler) addValueToEvent(evt *zerolog.Event, key string, value slog.Value) {
+	switch value.Kind() {
+	case slog.KindGroup:
+		group := value.Group()
+		if key == "" {
+			for _, a := range group {
+				h.addAttrToEvent(evt, a)
+			}
+			return
+		}
+		dict := zerolog.Dict()
+		for _, a := range group {
+			h.addAttrToEvent(dict, a)
+		}
+		evt.Dict(key, dict)
+	case slog.KindBool:
+		evt.Bool(key, value.Bool())
+	case slog.KindDuration:
+		evt.Dur(key, value.Duration())
+	case slog.KindFloat64:
+		evt.Float64(key, value.Float64())
+	case slog.KindInt64:
+		evt.Int64(key, value.Int64())
+	case slog.KindString:
+		evt.Str(key, value.String())
+	case slog.KindTime:
+		evt.Time(key, value.Time())
+	case slog.KindUint64:
+		evt.Uint64(key, value.Uint64())
+	case slog.KindAny:
+		h.addAnyToEvent(evt, key, value.Any())
+	default:
+		evt.Any(key, value.Any())
+	}
+}
+
+func (h *Handler) addAnyToEvent(evt *zerolog.Event, key string, value any) {
+	if err, ok := value.(error); ok {
+		evt.AnErr(key, err)
+		return
+	}
+	if stringer, ok := value.(fmt.Stringer); ok {
+		evt.Stringer(key, stringer)
+		return
+	}
+	evt.Any(key, value)
+}
+
+// addStructuredErrorToEvent logs err under key like any other error, and additionally emits a
+// companion field (key+h.detailsFieldSuffix) containing the error's resolved [slog.LogValuer]
+// value, so that errors carrying structured context (e.g. wrapped API error bodies) don't have
+// to choose between being logged as an error and being logged with their details.
+//
+// If LogValue returns the same error itself, the companion field is skipped to avoid an
+// infinite loop.
+func (h *Handler) addStructuredErrorToEvent(evt *zerolog.Event, key string, err error) {
+	evt.AnErr(key, err)
+	details, ok := h.resolveErrorDetails(err)
+	if !ok {
+		return
+	}
+	h.addValueToEvent(evt, key+h.detailsFieldSuffix, details)
+}
+
+// resolveErrorDetails returns the value err's LogValue should contribute to the companion
+// details field. It bails out (ok == false) if LogValue returns err itself, which would
+// otherwise recurse forever between the error field and its details field.
+func (h *Handler) resolveErrorDetails(err error) (value slog.Value, ok bool) {
+	lv, ok := err.(slog.LogValuer)
+	if !ok {
+		return slog.Value{}, false
+	}
+	raw := lv.LogValue()
+	if same, ok := raw.Any().(error); ok && same == err {
+		return slog.Value{}, false
+	}
+	return raw.Resolve(), true
+}
@@ -0,0 +1,143 @@
+// Copyright (c) 2024 Tulir Asokan
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package zeroslog_test
+
+import (
+	"log/slog"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/rs/zerolog"
+
+	"go.mau.fi/zerozap/zeroslog"
+)
+
+var timeFieldRe = regexp.MustCompile(`"time":"[^"]*",`)
+
+// withoutTime strips the non-deterministic time field slog.Logger stamps onto every record.
+func withoutTime(s string) string {
+	return timeFieldRe.ReplaceAllString(s, "")
+}
+
+func TestNew(t *testing.T) {
+	var buf strings.Builder
+	zlog := zerolog.New(&buf)
+
+	slogger := slog.New(zeroslog.New(zlog, nil))
+	slogger.Info("Hello, world!")
+
+	const expected = `{"level":"info","message":"Hello, world!"}` + "\n"
+	if out := withoutTime(buf.String()); out != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, out)
+	}
+}
+
+type valuerError struct {
+	msg    string
+	status int
+}
+
+func (e *valuerError) Error() string {
+	return e.msg
+}
+
+func (e *valuerError) LogValue() slog.Value {
+	return slog.GroupValue(slog.Int("status", e.status))
+}
+
+func TestHandler(t *testing.T) {
+	tests := []struct {
+		name     string
+		expected string
+		fn       func(*slog.Logger)
+	}{
+		{
+			name:     "Generic",
+			expected: `{"level":"info","int":42,"str":"meow","message":"Normal fields"}` + "\n",
+			fn: func(logger *slog.Logger) {
+				logger.Info("Normal fields", "int", 42, "str", "meow")
+			},
+		},
+		{
+			name:     "Group",
+			expected: `{"level":"info","meow":{"subfield":1},"message":"Grouped fields"}` + "\n",
+			fn: func(logger *slog.Logger) {
+				logger.WithGroup("meow").Info("Grouped fields", "subfield", 1)
+			},
+		},
+		{
+			name:     "StructuredError",
+			expected: `{"level":"error","err":"boom","errDetails":{"status":503},"message":"Request failed"}` + "\n",
+			fn: func(logger *slog.Logger) {
+				logger.Error("Request failed", "err", &valuerError{msg: "boom", status: 503})
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var buf strings.Builder
+			zlog := zerolog.New(&buf)
+			test.fn(slog.New(zeroslog.New(zlog, nil)))
+			if out := withoutTime(buf.String()); out != test.expected {
+				t.Errorf("expected:\n%s\ngot:\n%s", test.expected, out)
+			}
+		})
+	}
+}
+
+func TestHandler_GroupAttrsAcrossHandleCalls(t *testing.T) {
+	// WithGroup+With is the idiomatic way to build a request-scoped logger in slog; the group it
+	// opens must stay open (and not collide under a duplicate key) across more than one Handle call.
+	var buf strings.Builder
+	zlog := zerolog.New(&buf)
+	logger := slog.New(zeroslog.New(zlog, nil)).WithGroup("a").With("b", 1)
+
+	logger.Info("first", "c", 2)
+	logger.Info("second", "d", 3)
+
+	expected := `{"level":"info","a":{"b":1,"c":2},"message":"first"}
+{"level":"info","a":{"b":1,"d":3},"message":"second"}
+`
+	if out := withoutTime(buf.String()); out != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, out)
+	}
+}
+
+func TestHandler_EmptyGroupElided(t *testing.T) {
+	var buf strings.Builder
+	zlog := zerolog.New(&buf)
+	// A group that never receives any attrs must be omitted entirely, not written out as "a":{}.
+	slog.New(zeroslog.New(zlog, nil)).WithGroup("a").Info("handled")
+
+	const expected = `{"level":"info","message":"handled"}` + "\n"
+	if out := withoutTime(buf.String()); out != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, out)
+	}
+}
+
+func TestHandler_StructuredErrorSelfReference(t *testing.T) {
+	var buf strings.Builder
+	zlog := zerolog.New(&buf)
+	logger := slog.New(zeroslog.New(zlog, nil))
+
+	err := &selfValuerError{}
+	logger.Error("Loop guard", "err", err)
+
+	const expected = `{"level":"error","err":"loop","message":"Loop guard"}` + "\n"
+	if out := withoutTime(buf.String()); out != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, out)
+	}
+}
+
+type selfValuerError struct{}
+
+func (e *selfValuerError) Error() string { return "loop" }
+
+func (e *selfValuerError) LogValue() slog.Value {
+	return slog.AnyValue(error(e))
+}
@@ -7,11 +7,7 @@
 package zerozap
 
 import (
-	"encoding/base64"
 	"fmt"
-	"math"
-	"strconv"
-	"time"
 
 	"github.com/rs/zerolog"
 	"go.uber.org/zap"
@@ -30,17 +26,34 @@ var levelMap = map[zapcore.Level]zerolog.Level{
 
 type zeroZap struct {
 	zerolog.Logger
+	opts options
+
+	// pending holds a NamespaceType field and everything added after it (across any number of
+	// further With calls) once that namespace is still open. zap has no "close namespace" field,
+	// so once one is opened it stays open for the rest of the logger's life: it can only be fully
+	// nested (and the underlying zerolog.Context sealed into logWith.Logger()) once the complete
+	// field list is known, which isn't until the final Write. See With and Write.
+	pending []zapcore.Field
 }
 
 // New creates a new [zapcore.Core] using the given zerolog instance.
-func New(log zerolog.Logger) zapcore.Core {
-	return &zeroZap{Logger: log}
+//
+// By default, the returned core copies the time, stack and caller fields from zap's [zapcore.Entry]
+// into zerolog, using the deprecated [CopyTime], [CopyStack] and [CopyCaller] globals to seed
+// those defaults. Pass [CoreOption]s (e.g. [WithCopyTime]) to configure this per core instead,
+// which is required when embedding multiple zap loggers with different copy policies.
+func New(log zerolog.Logger, opts ...CoreOption) zapcore.Core {
+	o := defaultOptions()
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return &zeroZap{Logger: log, opts: o}
 }
 
 // Option creates a [zap.Option] that will replace the core of an existing zap logger with a ZeroZap core.
-func Option(log zerolog.Logger) zap.Option {
+func Option(log zerolog.Logger, opts ...CoreOption) zap.Option {
 	return zap.WrapCore(func(_ zapcore.Core) zapcore.Core {
-		return New(log)
+		return New(log, opts...)
 	})
 }
 
@@ -51,80 +64,83 @@ func (z *zeroZap) SetLogger(log zerolog.Logger) {
 var _ zapcore.Core = (*zeroZap)(nil)
 
 func (z *zeroZap) Enabled(level zapcore.Level) bool {
-	return z.GetLevel() <= levelMap[level]
+	return z.GetLevel() <= z.opts.levelMap[level]
 }
 
 func (z *zeroZap) With(fields []zapcore.Field) zapcore.Core {
+	if len(z.pending) > 0 {
+		// A namespace opened in an earlier With call is still open (zap has no "close namespace"
+		// field), so every field added from here on, however deeply nested, belongs inside it:
+		// keep deferring instead of trying to seal a Dict before we've seen the whole field list.
+		pending := make([]zapcore.Field, 0, len(z.pending)+len(fields))
+		pending = append(pending, z.pending...)
+		pending = append(pending, fields...)
+		return &zeroZap{Logger: z.Logger, opts: z.opts, pending: pending}
+	}
 	logWith := z.Logger.With()
-	for _, f := range fields {
+	for i, f := range fields {
 		switch f.Type {
 		case zapcore.ArrayMarshalerType:
-			ap := &arrayProxy{arr: f.Interface.(zapcore.ArrayMarshaler)}
-			logWith = logWith.Array(f.Key, ap)
-			// TODO why doesn't this function return errors when AddObject and others do?
-			if ap.err != nil {
-				panic(ap.err)
-			}
+			logWith = z.safeEncodeWith(f.Key, logWith, func(logWith zerolog.Context) zerolog.Context {
+				ap := &arrayProxy{arr: f.Interface.(zapcore.ArrayMarshaler)}
+				logWith = logWith.Array(f.Key, ap)
+				// TODO why doesn't this function return errors when AddObject and others do?
+				if ap.err != nil {
+					panic(ap.err)
+				}
+				return logWith
+			})
 		case zapcore.ObjectMarshalerType:
-			op := &objectProxy{obj: f.Interface.(zapcore.ObjectMarshaler)}
-			logWith = logWith.Object(f.Key, op)
-			if op.err != nil {
-				panic(op.err)
-			}
+			logWith = z.safeEncodeWith(f.Key, logWith, func(logWith zerolog.Context) zerolog.Context {
+				op := &objectProxy{obj: f.Interface.(zapcore.ObjectMarshaler)}
+				logWith = logWith.Object(f.Key, op)
+				if op.err != nil {
+					panic(op.err)
+				}
+				return logWith
+			})
 		case zapcore.InlineMarshalerType:
-			op := &objectProxy{obj: f.Interface.(zapcore.ObjectMarshaler)}
-			logWith = logWith.EmbedObject(op)
-			if op.err != nil {
-				panic(op.err)
-			}
-		case zapcore.BinaryType:
-			logWith = logWith.Str(f.Key, base64.StdEncoding.EncodeToString(f.Interface.([]byte)))
-		case zapcore.BoolType:
-			logWith = logWith.Bool(f.Key, f.Integer == 1)
-		case zapcore.ByteStringType:
-			logWith = logWith.Bytes(f.Key, f.Interface.([]byte))
-		case zapcore.Complex128Type:
-			logWith = logWith.Str(f.Key, strconv.FormatComplex(f.Interface.(complex128), 'f', -1, 128))
-		case zapcore.Complex64Type:
-			logWith = logWith.Str(f.Key, strconv.FormatComplex(complex128(f.Interface.(complex64)), 'f', -1, 64))
-		case zapcore.DurationType:
-			logWith = logWith.Dur(f.Key, time.Duration(f.Integer))
-		case zapcore.Float64Type:
-			logWith = logWith.Float64(f.Key, math.Float64frombits(uint64(f.Integer)))
-		case zapcore.Float32Type:
-			logWith = logWith.Float32(f.Key, math.Float32frombits(uint32(f.Integer)))
-		case zapcore.Int64Type, zapcore.Int32Type, zapcore.Int16Type, zapcore.Int8Type:
-			logWith = logWith.Int64(f.Key, f.Integer)
-		case zapcore.Uint64Type, zapcore.Uint32Type, zapcore.Uint16Type, zapcore.Uint8Type, zapcore.UintptrType:
-			logWith = logWith.Uint64(f.Key, uint64(f.Integer))
-		case zapcore.StringType:
-			logWith = logWith.Str(f.Key, f.String)
-		case zapcore.TimeType:
-			if f.Interface != nil {
-				logWith = logWith.Time(f.Key, time.Unix(0, f.Integer).In(f.Interface.(*time.Location)))
-			} else {
-				// Fall back to UTC if location is nil.
-				logWith = logWith.Time(f.Key, time.Unix(0, f.Integer))
-			}
-		case zapcore.TimeFullType:
-			logWith = logWith.Time(f.Key, f.Interface.(time.Time))
-		case zapcore.ReflectType:
-			logWith = logWith.Any(f.Key, f.Interface)
+			logWith = z.safeEncodeWith(f.Key, logWith, func(logWith zerolog.Context) zerolog.Context {
+				op := &objectProxy{obj: f.Interface.(zapcore.ObjectMarshaler)}
+				logWith = logWith.EmbedObject(op)
+				if op.err != nil {
+					panic(op.err)
+				}
+				return logWith
+			})
 		case zapcore.NamespaceType:
-			// TODO implement
-			panic("unsupported field type namespace")
-		case zapcore.StringerType:
-			// TODO catch panics like zap does in encodeStringer?
-			logWith = logWith.Stringer(f.Key, f.Interface.(fmt.Stringer))
-		case zapcore.ErrorType:
-			logWith = logWith.AnErr(f.Key, f.Interface.(error))
-		case zapcore.SkipType:
-			// noop
+			// Unlike the other field types, a namespace can't be sealed into logWith right away:
+			// zap has no "close namespace" field, so it (and everything nested under it) stays
+			// open through any number of further With calls and the eventual Write. Remember it
+			// instead and resume deferring from here; see the pending field doc comment and Write.
+			return &zeroZap{Logger: logWith.Logger(), opts: z.opts, pending: append([]zapcore.Field{}, fields[i:]...)}
 		default:
-			panic(fmt.Sprintf("unknown field type: %v", f))
+			logWith = z.safeEncodeWith(f.Key, logWith, func(logWith zerolog.Context) zerolog.Context {
+				return encodeContextField(f, logWith)
+			})
+		}
+	}
+	return &zeroZap{Logger: logWith.Logger(), opts: z.opts}
+}
+
+// safeEncodeWith runs fn, recovering a panic from a malformed field (e.g. a failing
+// ArrayMarshaler/ObjectMarshaler, or a bad type assertion on a corrupt zapcore.Field) and routing
+// it through z.opts.errorHandler instead of letting it crash the caller. See handleFieldError.
+func (z *zeroZap) safeEncodeWith(key string, logWith zerolog.Context, fn func(zerolog.Context) zerolog.Context) (result zerolog.Context) {
+	defer func() {
+		if r := recover(); r != nil {
+			result = z.handleFieldErrorWith(key, r, logWith)
 		}
+	}()
+	return fn(logWith)
+}
+
+func (z *zeroZap) handleFieldErrorWith(key string, recovered any, logWith zerolog.Context) zerolog.Context {
+	if z.opts.errorHandler != nil {
+		z.opts.errorHandler(fmt.Errorf("zerozap: encoding field %q: %v", key, recovered))
+		return logWith
 	}
-	return &zeroZap{Logger: logWith.Logger()}
+	return logWith.Str(key+"Error", fmt.Sprint(recovered))
 }
 
 func (z *zeroZap) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
@@ -137,109 +153,110 @@ func (z *zeroZap) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.Ch
 var (
 	// CopyTime controls whether the time field from zap is copied to zerolog.
 	// The time will be placed in [zerolog.TimestampFieldName].
+	//
+	// Deprecated: this only seeds the default for cores created without [WithCopyTime], and will
+	// be removed in a future release. Pass [WithCopyTime] to [New] instead.
 	CopyTime = true
 	// CopyStack controls whether the stack field from zap (if present) is copied to zerolog.
 	// The stack string will be placed in [zerolog.ErrorStackFieldName].
+	//
+	// Deprecated: this only seeds the default for cores created without [WithCopyStack], and will
+	// be removed in a future release. Pass [WithCopyStack] to [New] instead.
 	CopyStack = true
 	// CopyCaller controls whether the caller field from zap (if present) is copied to zerolog.
 	// The caller info will be placed in [zerolog.CallerFieldName] after being marshaled using [zerolog.CallerMarshalFunc].
+	//
+	// Deprecated: this only seeds the default for cores created without [WithCopyCaller], and will
+	// be removed in a future release. Pass [WithCopyCaller] to [New] instead.
 	CopyCaller = true
 )
 
 func (z *zeroZap) Write(entry zapcore.Entry, fields []zapcore.Field) error {
-	evt := z.WithLevel(levelMap[entry.Level])
-	if CopyTime {
+	evt := z.WithLevel(z.opts.levelMap[entry.Level])
+	if z.opts.copyTime {
 		evt.Time(zerolog.TimestampFieldName, entry.Time)
 	}
-	if entry.Stack != "" && CopyStack {
-		evt.Str(zerolog.ErrorStackFieldName, entry.Stack)
+	if entry.Stack != "" && z.opts.copyStack {
+		evt.Str(z.opts.stackFieldName, entry.Stack)
 	}
-	if entry.Caller.Defined && CopyCaller {
-		evt.Str(zerolog.CallerFieldName, zerolog.CallerMarshalFunc(entry.Caller.PC, entry.Caller.File, entry.Caller.Line))
+	if entry.Caller.Defined && z.opts.copyCaller {
+		evt.Str(zerolog.CallerFieldName, z.opts.callerMarshaler(entry.Caller.PC, entry.Caller.File, entry.Caller.Line))
 	}
-	err := fieldsToEvent(fields, evt)
-	if err != nil {
-		return err
+	if len(z.pending) > 0 {
+		// Still-open namespace from an earlier With call: fields is only the rest of the list, so
+		// prepend it to get the full, correctly ordered field list before nesting it. See pending.
+		fields = append(append(make([]zapcore.Field, 0, len(z.pending)+len(fields)), z.pending...), fields...)
 	}
+	z.fieldsToEvent(fields, evt)
 	evt.Msg(entry.Message)
 	return nil
 }
 
-func fieldsToEvent(fields []zapcore.Field, evt *zerolog.Event) error {
+// fieldsToEvent writes fields to evt, recovering from a panic in any single field (a failing
+// marshaler or a bad type assertion on a corrupt zapcore.Field) so that one bad log call can't
+// crash the process. Unknown field types don't panic: encodeField writes them with Event.Any.
+// See handleFieldError.
+func (z *zeroZap) fieldsToEvent(fields []zapcore.Field, evt *zerolog.Event) {
 	for i, f := range fields {
 		switch f.Type {
 		case zapcore.ArrayMarshalerType:
-			ap := &arrayProxy{arr: f.Interface.(zapcore.ArrayMarshaler)}
-			evt.Array(f.Key, ap)
-			if ap.err != nil {
-				return ap.err
-			}
+			z.safeEncode(f.Key, evt, func() {
+				ap := &arrayProxy{arr: f.Interface.(zapcore.ArrayMarshaler)}
+				evt.Array(f.Key, ap)
+				if ap.err != nil {
+					panic(ap.err)
+				}
+			})
 		case zapcore.ObjectMarshalerType:
-			op := &objectProxy{obj: f.Interface.(zapcore.ObjectMarshaler)}
-			evt.Object(f.Key, op)
-			if op.err != nil {
-				return op.err
-			}
+			z.safeEncode(f.Key, evt, func() {
+				op := &objectProxy{obj: f.Interface.(zapcore.ObjectMarshaler)}
+				evt.Object(f.Key, op)
+				if op.err != nil {
+					panic(op.err)
+				}
+			})
 		case zapcore.InlineMarshalerType:
-			op := &objectProxy{obj: f.Interface.(zapcore.ObjectMarshaler)}
-			evt.EmbedObject(op)
-			if op.err != nil {
-				return op.err
-			}
-		case zapcore.BinaryType:
-			evt.Str(f.Key, base64.StdEncoding.EncodeToString(f.Interface.([]byte)))
-		case zapcore.BoolType:
-			evt.Bool(f.Key, f.Integer == 1)
-		case zapcore.ByteStringType:
-			evt.Bytes(f.Key, f.Interface.([]byte))
-		case zapcore.Complex128Type:
-			evt.Str(f.Key, strconv.FormatComplex(f.Interface.(complex128), 'f', -1, 128))
-		case zapcore.Complex64Type:
-			evt.Str(f.Key, strconv.FormatComplex(complex128(f.Interface.(complex64)), 'f', -1, 64))
-		case zapcore.DurationType:
-			evt.Dur(f.Key, time.Duration(f.Integer))
-		case zapcore.Float64Type:
-			evt.Float64(f.Key, math.Float64frombits(uint64(f.Integer)))
-		case zapcore.Float32Type:
-			evt.Float32(f.Key, math.Float32frombits(uint32(f.Integer)))
-		case zapcore.Int64Type, zapcore.Int32Type, zapcore.Int16Type, zapcore.Int8Type:
-			evt.Int64(f.Key, f.Integer)
-		case zapcore.Uint64Type, zapcore.Uint32Type, zapcore.Uint16Type, zapcore.Uint8Type, zapcore.UintptrType:
-			evt.Uint64(f.Key, uint64(f.Integer))
-		case zapcore.StringType:
-			evt.Str(f.Key, f.String)
-		case zapcore.TimeType:
-			if f.Interface != nil {
-				evt.Time(f.Key, time.Unix(0, f.Integer).In(f.Interface.(*time.Location)))
-			} else {
-				// Fall back to UTC if location is nil.
-				evt.Time(f.Key, time.Unix(0, f.Integer))
-			}
-		case zapcore.TimeFullType:
-			evt.Time(f.Key, f.Interface.(time.Time))
-		case zapcore.ReflectType:
-			evt.Any(f.Key, f.Interface)
+			z.safeEncode(f.Key, evt, func() {
+				op := &objectProxy{obj: f.Interface.(zapcore.ObjectMarshaler)}
+				evt.EmbedObject(op)
+				if op.err != nil {
+					panic(op.err)
+				}
+			})
 		case zapcore.NamespaceType:
 			subEvt := zerolog.Dict()
-			err := fieldsToEvent(fields[i+1:], subEvt)
-			if err != nil {
-				return err
-			}
+			z.fieldsToEvent(fields[i+1:], subEvt)
 			evt.Dict(f.Key, subEvt)
 			// All fields were already consumed
-			return nil
-		case zapcore.StringerType:
-			// TODO catch panics like zap does in encodeStringer?
-			evt.Stringer(f.Key, f.Interface.(fmt.Stringer))
-		case zapcore.ErrorType:
-			evt.AnErr(f.Key, f.Interface.(error))
-		case zapcore.SkipType:
-			// noop
+			return
 		default:
-			return fmt.Errorf("unknown field type: %v", f)
+			z.safeEncode(f.Key, evt, func() {
+				encodeField(f, evt)
+			})
 		}
 	}
-	return nil
+}
+
+// safeEncode is the [zerolog.Event] counterpart of safeEncodeWith.
+func (z *zeroZap) safeEncode(key string, evt *zerolog.Event, fn func()) {
+	defer func() {
+		if r := recover(); r != nil {
+			z.handleFieldError(key, r, evt)
+		}
+	}()
+	fn()
+}
+
+// handleFieldError reports a panic recovered while encoding the field named key: if
+// [WithErrorHandler] is set, it's called with the panic value wrapped as an error; otherwise the
+// panic message is written to evt/logWith under "<key>Error" so the rest of the log call (and the
+// process) survives a single malformed field.
+func (z *zeroZap) handleFieldError(key string, recovered any, evt *zerolog.Event) {
+	if z.opts.errorHandler != nil {
+		z.opts.errorHandler(fmt.Errorf("zerozap: encoding field %q: %v", key, recovered))
+		return
+	}
+	evt.Str(key+"Error", fmt.Sprint(recovered))
 }
 
 func (z *zeroZap) Sync() error {
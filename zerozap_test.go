@@ -7,12 +7,16 @@
 package zerozap_test
 
 import (
+	"encoding/json"
+	"errors"
+	"fmt"
 	"os"
 	"strings"
 	"testing"
 
 	"github.com/rs/zerolog"
 	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
 
 	"go.mau.fi/zerozap"
 )
@@ -60,14 +64,110 @@ func TestZeroZap(t *testing.T) {
 				logger.Info("Array", zap.Strings("meow", []string{"me", "o", "w"}))
 			},
 		},
+		{
+			name:     "NamespacedWith",
+			expected: `{"level":"info","a":{"b":1},"message":"Namespaced with"}` + "\n",
+			fn: func(logger *zap.Logger) {
+				logger.With(zap.Namespace("a"), zap.Int("b", 1)).Info("Namespaced with")
+			},
+		},
+		{
+			name:     "NamespacedWithAcrossCalls",
+			expected: `{"level":"info","a":{"b":1,"c":2},"message":"Namespaced with, split across calls"}` + "\n",
+			fn: func(logger *zap.Logger) {
+				// A namespace has no "close" field in zap, so it must stay open across With calls
+				// (and the final log call) too, not just within the call that opened it.
+				logger.With(zap.Namespace("a")).With(zap.Int("b", 1)).Info("Namespaced with, split across calls", zap.Int("c", 2))
+			},
+		},
+		{
+			name:     "ArrayOfArrays",
+			expected: `{"level":"info","matrix":[[1,2],[3,4]],"message":"Matrix"}` + "\n",
+			fn: func(logger *zap.Logger) {
+				row := func(a, b int) zapcore.ArrayMarshaler {
+					return zapcore.ArrayMarshalerFunc(func(enc zapcore.ArrayEncoder) error {
+						enc.AppendInt(a)
+						enc.AppendInt(b)
+						return nil
+					})
+				}
+				matrix := zapcore.ArrayMarshalerFunc(func(enc zapcore.ArrayEncoder) error {
+					if err := enc.AppendArray(row(1, 2)); err != nil {
+						return err
+					}
+					return enc.AppendArray(row(3, 4))
+				})
+				logger.Info("Matrix", zap.Array("matrix", matrix))
+			},
+		},
 	}
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
 			var buf strings.Builder
-			test.fn(zap.New(&zerozap.ZeroZap{Logger: zerolog.New(&buf)}))
+			test.fn(zap.New(zerozap.New(zerolog.New(&buf))))
 			if out := buf.String(); out != test.expected {
 				t.Errorf("expected:\n%s\ngot:\n%s", test.expected, out)
 			}
 		})
 	}
 }
+
+func TestOptions(t *testing.T) {
+	var buf strings.Builder
+	logger := zap.New(zerozap.New(zerolog.New(&buf),
+		zerozap.WithCopyTime(true),
+		zerozap.WithStackFieldName("stacktrace"),
+		zerozap.WithLevelMap(map[zapcore.Level]zerolog.Level{zapcore.InfoLevel: zerolog.WarnLevel}),
+	))
+	logger.Info("Hello, world!")
+
+	var fields map[string]any
+	if err := json.Unmarshal([]byte(buf.String()), &fields); err != nil {
+		t.Fatalf("failed to unmarshal output: %v", err)
+	}
+	if _, ok := fields[zerolog.TimestampFieldName]; !ok {
+		t.Errorf("expected %q to be set when WithCopyTime(true) is passed", zerolog.TimestampFieldName)
+	}
+	if fields["level"] != "warn" {
+		t.Errorf("expected level to be remapped to warn, got %v", fields["level"])
+	}
+}
+
+func TestPanicSafety(t *testing.T) {
+	badArray := zap.Array("bad", zapcore.ArrayMarshalerFunc(func(enc zapcore.ArrayEncoder) error {
+		return errors.New("boom")
+	}))
+
+	t.Run("DefaultHandler", func(t *testing.T) {
+		var buf strings.Builder
+		logger := zap.New(zerozap.New(zerolog.New(&buf)))
+		logger.Info("Hello, world!", badArray)
+
+		var fields map[string]any
+		if err := json.Unmarshal([]byte(buf.String()), &fields); err != nil {
+			t.Fatalf("failed to unmarshal output: %v", err)
+		}
+		if !strings.Contains(fmt.Sprint(fields["badError"]), "boom") {
+			t.Errorf(`expected "badError" field containing the panic message, got %v`, fields["badError"])
+		}
+		if fields["message"] != "Hello, world!" {
+			t.Errorf("expected the rest of the entry to still be written, got %v", fields)
+		}
+	})
+
+	t.Run("CustomHandler", func(t *testing.T) {
+		var buf strings.Builder
+		var handled error
+		logger := zap.New(zerozap.New(zerolog.New(&buf), zerozap.WithErrorHandler(func(err error) {
+			handled = err
+		})))
+		logger.Info("Hello, world!", badArray)
+
+		if handled == nil || !strings.Contains(handled.Error(), "boom") {
+			t.Errorf("expected the error handler to receive the panic, got %v", handled)
+		}
+		if strings.Contains(buf.String(), "badError") {
+			t.Errorf(`expected "badError" to be omitted when a custom error handler is set, got %s`, buf.String())
+		}
+	})
+}